@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envSource resolves environment-style key/value pairs. os.LookupEnv is
+// the default, but callers can supply a .env file, a plain map (handy in
+// tests), or a chain of sources to fall back through.
+type envSource interface {
+	Lookup(key string) (string, bool)
+}
+
+type osEnvSource struct{}
+
+func (osEnvSource) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+// mapEnvSource resolves keys from an in-memory map, useful for tests and
+// for sources that have already been parsed (e.g. a .env file).
+type mapEnvSource map[string]string
+
+func (m mapEnvSource) Lookup(key string) (string, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+// chainEnvSource tries each source in order, returning the first hit.
+type chainEnvSource []envSource
+
+func (c chainEnvSource) Lookup(key string) (string, bool) {
+	for _, source := range c {
+		if v, ok := source.Lookup(key); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// newDotEnvSource reads a `.env` file of KEY=VALUE lines into a lookup
+// source. Blank lines and lines starting with '#' are ignored.
+func newDotEnvSource(path string) (envSource, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("conf: reading dotenv file: %w", err)
+	}
+
+	vars := mapEnvSource{}
+	for _, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		vars[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return vars, nil
+}
+
+var defaultEnvSource envSource = osEnvSource{}
+
+// confOptions holds the comma-separated options that follow the env var
+// name in a conf tag, e.g. `conf:"PORT,default=8080,required"`.
+type confOptions struct {
+	name      string
+	def       string
+	hasDef    bool
+	required  bool
+	prefix    string
+	separator string
+	mapsep    string
+	kvsep     string
+}
+
+func parseConfTag(tag string) confOptions {
+	parts := strings.Split(tag, ",")
+	opts := confOptions{name: parts[0]}
+	for _, part := range parts[1:] {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "default":
+			opts.def, opts.hasDef = value, true
+		case "required":
+			opts.required = true
+		case "prefix":
+			opts.prefix = value
+		case "separator":
+			opts.separator = value
+		case "mapsep":
+			opts.mapsep = value
+		case "kvsep":
+			opts.kvsep = value
+		}
+	}
+	return opts
+}
+
+// confPrefixFor reports the env-var prefix a nested struct field
+// contributes to its children, e.g. `conf:",prefix=DB_"`.
+func confPrefixFor(field reflect.StructField) (string, bool) {
+	tag, ok := field.Tag.Lookup("conf")
+	if !ok {
+		return "", false
+	}
+	opts := parseConfTag(tag)
+	if opts.prefix == "" {
+		return "", false
+	}
+	return opts.prefix, true
+}
+
+// handleConfigTag populates a field from the environment using the
+// default os.LookupEnv source. Use NewConfigHandler to supply a
+// different envSource (a .env file, a map, or a chain of both).
+func handleConfigTag(parent reflect.Value, value reflect.Value, field reflect.StructField, prefix string) error {
+	return NewConfigHandler(defaultEnvSource)(parent, value, field, prefix)
+}
+
+// NewConfigHandler builds a conf-tag HandlerFn that resolves values
+// through the given envSource, so callers aren't limited to os.LookupEnv.
+func NewConfigHandler(source envSource) HandlerFn {
+	return func(parent reflect.Value, value reflect.Value, field reflect.StructField, prefix string) error {
+		tag, ok := field.Tag.Lookup("conf")
+		if !ok {
+			return nil
+		}
+		opts := parseConfTag(tag)
+		if opts.name == "" {
+			return nil
+		}
+
+		raw, ok := source.Lookup(prefix + opts.name)
+		if !ok {
+			if opts.hasDef {
+				raw = opts.def
+			} else if opts.required {
+				return fmt.Errorf("conf: required env var %q is not set", prefix+opts.name)
+			} else {
+				return nil
+			}
+		}
+
+		if err := setConfValue(value, raw, opts); err != nil {
+			return fmt.Errorf("conf: field %s (%s): %w", field.Name, prefix+opts.name, err)
+		}
+		return nil
+	}
+}
+
+func setConfValue(value reflect.Value, raw string, opts confOptions) error {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		return setConfValue(value.Elem(), raw, opts)
+
+	case reflect.Slice:
+		elemType := value.Type().Elem()
+		if elemType.Kind() == reflect.Struct {
+			out := reflect.New(value.Type())
+			if err := json.Unmarshal([]byte(raw), out.Interface()); err != nil {
+				return fmt.Errorf("decoding json slice: %w", err)
+			}
+			value.Set(out.Elem())
+			return nil
+		}
+
+		parts := strings.Split(raw, orDefault(opts.separator, ","))
+		out := reflect.MakeSlice(value.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setScalar(out.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		value.Set(out)
+		return nil
+
+	case reflect.Map:
+		pairs := strings.Split(raw, orDefault(opts.mapsep, ","))
+		kvsep := orDefault(opts.kvsep, ":")
+		out := reflect.MakeMapWithSize(value.Type(), len(pairs))
+		for _, pair := range pairs {
+			k, v, found := strings.Cut(pair, kvsep)
+			if !found {
+				return fmt.Errorf("invalid map entry %q (want key%svalue)", pair, kvsep)
+			}
+			key := reflect.New(value.Type().Key()).Elem()
+			if err := setScalar(key, strings.TrimSpace(k)); err != nil {
+				return err
+			}
+			val := reflect.New(value.Type().Elem()).Elem()
+			if err := setScalar(val, strings.TrimSpace(v)); err != nil {
+				return err
+			}
+			out.SetMapIndex(key, val)
+		}
+		value.Set(out)
+		return nil
+
+	default:
+		return setScalar(value, raw)
+	}
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+func setScalar(value reflect.Value, raw string) error {
+	if value.CanAddr() && value.Addr().Type().Implements(textUnmarshalerType) {
+		return value.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported kind %s", value.Kind())
+	}
+	return nil
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
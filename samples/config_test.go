@@ -0,0 +1,150 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type withDefault struct {
+	Host string `conf:"HOST,default=localhost"`
+}
+
+func TestConfigDefaultFallback(t *testing.T) {
+	th := TagHandler{HandlerFn: NewConfigHandler(mapEnvSource{})}
+
+	var cfg withDefault
+	if err := th.Handle(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Host != "localhost" {
+		t.Fatalf("expected default %q, got %q", "localhost", cfg.Host)
+	}
+}
+
+type withRequired struct {
+	Host string `conf:"HOST,required"`
+}
+
+func TestConfigRequiredMissing(t *testing.T) {
+	th := TagHandler{HandlerFn: NewConfigHandler(mapEnvSource{})}
+
+	var cfg withRequired
+	if err := th.Handle(&cfg); err == nil {
+		t.Fatal("expected error: HOST is required but unset")
+	}
+}
+
+type withSeparator struct {
+	Origins []string `conf:"ORIGINS,separator=|"`
+}
+
+func TestConfigSliceSeparator(t *testing.T) {
+	th := TagHandler{HandlerFn: NewConfigHandler(mapEnvSource{"ORIGINS": "a.com|b.com|c.com"})}
+
+	var cfg withSeparator
+	if err := th.Handle(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"a.com", "b.com", "c.com"}
+	if len(cfg.Origins) != len(want) {
+		t.Fatalf("expected %v, got %v", want, cfg.Origins)
+	}
+	for i := range want {
+		if cfg.Origins[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, cfg.Origins)
+		}
+	}
+}
+
+type withMap struct {
+	Scores map[string]int `conf:"SCORES,mapsep=;,kvsep=:"`
+}
+
+func TestConfigMapSepKvsep(t *testing.T) {
+	th := TagHandler{HandlerFn: NewConfigHandler(mapEnvSource{"SCORES": "a:1;b:2"})}
+
+	var cfg withMap
+	if err := th.Handle(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Scores["a"] != 1 || cfg.Scores["b"] != 2 {
+		t.Fatalf("expected map[a:1 b:2], got %v", cfg.Scores)
+	}
+}
+
+func TestConfigNestedPrefix(t *testing.T) {
+	th := TagHandler{HandlerFn: NewConfigHandler(mapEnvSource{
+		"ELASTICSEARCH_HOST": "es.internal",
+		"DB_HOST":            "db.internal",
+		"DB_PORT":            "6543",
+	})}
+
+	var cfg Config
+	if err := th.Handle(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Database.Host != "db.internal" {
+		t.Fatalf("expected DB_HOST to populate Database.Host, got %q", cfg.Database.Host)
+	}
+	if cfg.Database.Port != 6543 {
+		t.Fatalf("expected DB_PORT to populate Database.Port, got %d", cfg.Database.Port)
+	}
+}
+
+func TestHandleConfigTagUsesOSEnv(t *testing.T) {
+	t.Setenv("HOST", "os.example.com")
+
+	var cfg withRequired
+	th := TagHandler{HandlerFn: handleConfigTag}
+	if err := th.Handle(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Host != "os.example.com" {
+		t.Fatalf("expected handleConfigTag to read HOST from the OS environment, got %q", cfg.Host)
+	}
+}
+
+func TestChainEnvSourceFallsThroughToNextSource(t *testing.T) {
+	chain := chainEnvSource{
+		mapEnvSource{},
+		mapEnvSource{"HOST": "fallback.example.com"},
+	}
+
+	th := TagHandler{HandlerFn: NewConfigHandler(chain)}
+	var cfg withRequired
+	if err := th.Handle(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Host != "fallback.example.com" {
+		t.Fatalf("expected the chain to fall through to the second source, got %q", cfg.Host)
+	}
+}
+
+func TestNewDotEnvSource(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	contents := "# comment\nHOST=dotenv.example.com\n\nPORT=1234\n"
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("failed to write dotenv fixture: %v", err)
+	}
+
+	source, err := newDotEnvSource(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if v, ok := source.Lookup("HOST"); !ok || v != "dotenv.example.com" {
+		t.Fatalf("expected HOST=dotenv.example.com, got %q (ok=%v)", v, ok)
+	}
+	if v, ok := source.Lookup("PORT"); !ok || v != "1234" {
+		t.Fatalf("expected PORT=1234, got %q (ok=%v)", v, ok)
+	}
+	if _, ok := source.Lookup("MISSING"); ok {
+		t.Fatal("expected MISSING to be absent")
+	}
+
+	if _, err := newDotEnvSource(filepath.Join(dir, "nope.env")); err == nil {
+		t.Fatal("expected an error reading a nonexistent dotenv file")
+	}
+}
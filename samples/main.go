@@ -1,82 +1,197 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
 	"reflect"
-	"regexp"
-	"strconv"
 )
 
+// HandlerFn is invoked once per struct field during a walk. parent is the
+// struct the field belongs to, which tag handlers that need to look at
+// sibling fields (e.g. eqfield cross-field rules) can inspect. prefix is
+// the accumulated env-var prefix for the branch of the tree currently
+// being walked, set by ancestor fields carrying a `conf:",prefix=..."`
+// option; handlers that don't care about prefixes simply ignore it.
+type HandlerFn func(parent reflect.Value, value reflect.Value, field reflect.StructField, prefix string) error
+
+// ErrSkipField, returned by a HandlerFn, stops any remaining registered
+// handlers from running on that field without aborting the rest of the
+// walk.
+var ErrSkipField = errors.New("taghandler: skip remaining handlers for this field")
+
+// ErrMaxDepthExceeded is returned when a walk recurses past TagHandler's
+// MaxDepth, which guards against stack overflow on deep or cyclic graphs.
+var ErrMaxDepthExceeded = errors.New("taghandler: max depth exceeded")
+
+// defaultMaxDepth is used when TagHandler.MaxDepth is left at zero.
+const defaultMaxDepth = 64
+
 type TagHandler struct {
-	HandlerFn func(value reflect.Value, field reflect.StructField) error
+	// HandlerFn is the single-handler form, kept for backward
+	// compatibility: it runs as-is as long as Register hasn't been
+	// called. Once handlers have been registered, they take over
+	// dispatch instead.
+	HandlerFn HandlerFn
+
+	// MaxDepth caps how many levels of struct nesting a walk will follow
+	// before returning ErrMaxDepthExceeded. Zero means defaultMaxDepth.
+	MaxDepth int
+
+	handlers map[string]HandlerFn
+	order    []string
 }
 
-func (th TagHandler) Handle(v interface{}) error {
-	return th.handleValue(reflect.ValueOf(v))
+func (th *TagHandler) maxDepth() int {
+	if th.MaxDepth > 0 {
+		return th.MaxDepth
+	}
+	return defaultMaxDepth
 }
 
-func (th TagHandler) handleValue(val reflect.Value) error {
-	kind := val.Kind()
-	switch kind {
+// Register adds fn under tagName to the dispatch registry. handleStruct
+// then runs every registered handler on each field, in registration
+// order unless SetOrder overrides it.
+func (th *TagHandler) Register(tagName string, fn HandlerFn) *TagHandler {
+	if th.handlers == nil {
+		th.handlers = map[string]HandlerFn{}
+	}
+	if _, exists := th.handlers[tagName]; !exists {
+		th.order = append(th.order, tagName)
+	}
+	th.handlers[tagName] = fn
+	return th
+}
+
+// SetOrder fixes the order tag names are dispatched in, e.g. to ensure
+// conf runs before validate so validation sees env-loaded values.
+func (th *TagHandler) SetOrder(order []string) *TagHandler {
+	th.order = order
+	return th
+}
+
+func (th *TagHandler) Handle(v interface{}) error {
+	return th.handleValue(reflect.ValueOf(v), "", map[uintptr]struct{}{}, 0)
+}
+
+func (th *TagHandler) handleValue(val reflect.Value, prefix string, visited map[uintptr]struct{}, depth int) error {
+	if depth > th.maxDepth() {
+		return ErrMaxDepthExceeded
+	}
+
+	switch val.Kind() {
 	case reflect.Struct:
-		return th.handleStruct(val)
-	case reflect.Array, reflect.Slice:
-		return th.handleArray(val)
+		return th.handleStruct(val, prefix, visited, depth)
+	case reflect.Array:
+		return th.handleArray(val, prefix, visited, depth)
+	case reflect.Slice:
+		return th.withCycleGuard(val, visited, func() error {
+			return th.handleArray(val, prefix, visited, depth)
+		})
 	case reflect.Map:
-		return th.handleMap(val)
+		return th.withCycleGuard(val, visited, func() error {
+			return th.handleMap(val, prefix, visited, depth)
+		})
 	case reflect.Ptr:
-		return th.handleValue(val.Elem())
+		if val.IsNil() {
+			return nil
+		}
+		return th.withCycleGuard(val, visited, func() error {
+			return th.handleValue(val.Elem(), prefix, visited, depth)
+		})
 	}
 	return nil
 }
 
-func (th TagHandler) handleStruct(val reflect.Value) error {
+// withCycleGuard records val's underlying pointer as visited for the
+// duration of walk, so a graph that loops back on itself (e.g. Person.A
+// friends Person.B who friends Person.A back) stops at a node that is
+// already on the current path instead of recursing forever. A node
+// reachable along several paths is walked once per path. The pointer is
+// only tracked for
+// reflect.Ptr, reflect.Map and reflect.Slice, the kinds whose Pointer()
+// identifies shared underlying data.
+func (th *TagHandler) withCycleGuard(val reflect.Value, visited map[uintptr]struct{}, walk func() error) error {
+	if val.IsNil() {
+		return nil
+	}
+	ptr := val.Pointer()
+	if _, seen := visited[ptr]; seen {
+		return nil
+	}
+	visited[ptr] = struct{}{}
+	defer delete(visited, ptr)
+	return walk()
+}
+
+func (th *TagHandler) handleStruct(val reflect.Value, prefix string, visited map[uintptr]struct{}, depth int) error {
 	typ := val.Type()
+	var errs []error
 	for i := 0; i < val.NumField(); i++ {
-		if err := th.HandlerFn(val.Field(i), typ.Field(i)); err != nil {
-			return err
+		field := typ.Field(i)
+		if err := th.dispatch(val, val.Field(i), field, prefix); err != nil {
+			errs = append(errs, err)
+		}
+
+		childPrefix := prefix
+		if p, ok := confPrefixFor(field); ok {
+			childPrefix = prefix + p
 		}
-		if err := th.handleValue(val.Field(i)); err != nil {
-			return err
+		if err := th.handleValue(val.Field(i), childPrefix, visited, depth+1); err != nil {
+			errs = append(errs, err)
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
-func (th TagHandler) handleArray(val reflect.Value) error {
-	for i := 0; i < val.Len(); i++ {
-		if err := th.handleValue(val.Index(i)); err != nil {
-			return err
+// dispatch runs every tag handler registered for a field, in order,
+// collecting their errors instead of stopping at the first one so a bad
+// field in a struct doesn't hide problems in its siblings. A handler may
+// return ErrSkipField to stop the remaining handlers for this field
+// without that counting as a failure.
+func (th *TagHandler) dispatch(parent, value reflect.Value, field reflect.StructField, prefix string) error {
+	if len(th.handlers) == 0 {
+		if th.HandlerFn == nil {
+			return nil
 		}
+		return th.HandlerFn(parent, value, field, prefix)
 	}
-	return nil
-}
 
-func (th TagHandler) handleMap(val reflect.Value) error {
-	for _, key := range val.MapKeys() {
-		if err := th.handleValue(val.MapIndex(key)); err != nil {
-			return err
+	var errs []error
+	for _, name := range th.order {
+		fn, ok := th.handlers[name]
+		if !ok {
+			continue
+		}
+		err := fn(parent, value, field, prefix)
+		if errors.Is(err, ErrSkipField) {
+			break
+		}
+		if err != nil {
+			errs = append(errs, err)
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
-func handleValidateTag(value reflect.Value, field reflect.StructField) error {
-	tag, ok := field.Tag.Lookup("validate")
-	if !ok {
-		return nil
-	}
-	match, err := regexp.Compile(tag)
-	if err != nil {
-		return fmt.Errorf("validation regexp syntax error: %v", err)
+func (th *TagHandler) handleArray(val reflect.Value, prefix string, visited map[uintptr]struct{}, depth int) error {
+	var errs []error
+	for i := 0; i < val.Len(); i++ {
+		if err := th.handleValue(val.Index(i), prefix, visited, depth); err != nil {
+			errs = append(errs, err)
+		}
 	}
+	return errors.Join(errs...)
+}
 
-	str := valueToString(value)
-	if !match.MatchString(str) {
-		return fmt.Errorf("invalid field (%v::%v) %v != %v", field.Type, field.Name, str, tag)
+func (th *TagHandler) handleMap(val reflect.Value, prefix string, visited map[uintptr]struct{}, depth int) error {
+	var errs []error
+	for _, key := range val.MapKeys() {
+		if err := th.handleValue(val.MapIndex(key), prefix, visited, depth); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func valueToString(value reflect.Value) string {
@@ -84,9 +199,9 @@ func valueToString(value reflect.Value) string {
 }
 
 type Person struct {
-	BirthYear int       `json:"birth_year" validate:"^(19|20)\\d\\d$"`
+	BirthYear int       `json:"birth_year" validate:"required,gte=1900,lte=2025"`
 	Name      Name      `json:"name"`
-	Email     string    `json:"email" validate:"^[\\w-\\.]+@([\\w-]+\\.)+[\\w-]{2,4}$"`
+	Email     string    `json:"email" validate:"required,email"`
 	Friends   []*Person `json:"friends"`
 }
 
@@ -96,47 +211,21 @@ type Name struct {
 }
 
 type Config struct {
-	HttpMaxRetries    int    `conf:"HTTP_MAX_RETRIES"`
-	ElasticsearchHost string `conf:"ELASTICSEARCH_HOST"`
+	HttpMaxRetries    int      `conf:"HTTP_MAX_RETRIES,default=3" validate:"gte=0,lte=10"`
+	ElasticsearchHost string   `conf:"ELASTICSEARCH_HOST,required" validate:"required"`
+	AllowedOrigins    []string `conf:"ALLOWED_ORIGINS,default=*"`
+	Database          Database `conf:",prefix=DB_"`
 }
 
-func handleConfigTag(value reflect.Value, field reflect.StructField) error {
-	tag, ok := field.Tag.Lookup("conf")
-	if !ok {
-		return nil
-	}
-	envvar, ok := os.LookupEnv(tag)
-	if !ok {
-		return nil
-	}
-	return setValue(value, envvar)
+type Database struct {
+	Host string `conf:"HOST,default=localhost"`
+	Port int    `conf:"PORT,default=5432"`
 }
 
-func setValue(value reflect.Value, envvar string) error {
-	switch value.Kind() {
-	case reflect.String:
-		value.SetString(envvar)
-	case reflect.Int:
-		n, err := strconv.Atoi(envvar)
-		if err != nil {
-			return err
-		}
-		value.SetInt(int64(n))
-	}
-	return nil
-}
-
-//segments := strings.Split(tag, ";")
-//if len(segments) != 2 {
-//return fmt.Errorf("invalid configuration tag specified: %s", tag)
-//}
-
 func main() {
-	th := TagHandler{
-		HandlerFn: handleValidateTag,
-	}
+	validator := TagHandler{HandlerFn: handleValidateTag}
 
-	err := th.Handle(Person{
+	err := validator.Handle(Person{
 		Name: Name{
 			FirstName: "Lasse Martin",
 			LastName:  "Jakobsen",
@@ -156,19 +245,43 @@ func main() {
 		return
 	}
 
-	cfgHandler := TagHandler{
-		HandlerFn: handleConfigTag,
+	// Look keys up in a local .env file first, so a developer can override
+	// defaults (and the real environment) without exporting anything;
+	// chainEnvSource falls through to the OS when .env is absent or a key
+	// isn't in it.
+	source := chainEnvSource{defaultEnvSource}
+	if dotenv, err := newDotEnvSource(".env"); err == nil {
+		source = chainEnvSource{dotenv, defaultEnvSource}
 	}
 
+	// Loading Config needs both concerns: conf populates the fields from
+	// the environment, then validate checks what came out. Registering
+	// both on one TagHandler does it in a single walk instead of two.
+	cfgHandler := (&TagHandler{}).
+		Register("conf", NewConfigHandler(source)).
+		Register("validate", handleValidateTag).
+		SetOrder([]string{"conf", "validate"})
+
 	var cfg Config
-	err = cfgHandler.Handle(&cfg)
-	if err != nil {
+	if err := cfgHandler.Handle(&cfg); err != nil {
 		fmt.Println(err)
 		return
 	}
 
-	fmt.Printf(`ElasticsearchHost: %s, HttpMaxRetries: %d\n`,
-		cfg.ElasticsearchHost, cfg.HttpMaxRetries)
+	fmt.Printf("ElasticsearchHost: %s, HttpMaxRetries: %d, DB: %s:%d\n",
+		cfg.ElasticsearchHost, cfg.HttpMaxRetries, cfg.Database.Host, cfg.Database.Port)
+
+	schema, err := (&TagHandler{}).Schema(Person{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	out, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(string(out))
 }
 
 //Email string `json:"email" validate:"^[\\w-\\.]+@([\\w-]+\\.)+[\\w-]{2,4}$"`
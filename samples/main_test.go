@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestHandleCyclicGraph(t *testing.T) {
+	a := &Person{Name: Name{FirstName: "A"}, BirthYear: 1990, Email: "a@example.com"}
+	b := &Person{Name: Name{FirstName: "B"}, BirthYear: 1991, Email: "b@example.com"}
+	a.Friends = []*Person{b}
+	b.Friends = []*Person{a}
+
+	th := TagHandler{HandlerFn: handleValidateTag}
+	if err := th.Handle(a); err != nil {
+		t.Fatalf("unexpected error walking a valid cyclic graph: %v", err)
+	}
+}
+
+func TestHandleNilFriend(t *testing.T) {
+	p := &Person{
+		Name:      Name{FirstName: "A"},
+		BirthYear: 1990,
+		Email:     "a@example.com",
+		Friends:   []*Person{nil},
+	}
+
+	th := TagHandler{HandlerFn: handleValidateTag}
+	if err := th.Handle(p); err != nil {
+		t.Fatalf("unexpected error walking a nil friend: %v", err)
+	}
+}
+
+func TestHandleMaxDepthExceeded(t *testing.T) {
+	root := &Person{Name: Name{FirstName: "P0"}, BirthYear: 2000, Email: "p0@example.com"}
+	cur := root
+	for i := 0; i < defaultMaxDepth+10; i++ {
+		next := &Person{Name: Name{FirstName: "P"}, BirthYear: 2000, Email: "p@example.com"}
+		cur.Friends = []*Person{next}
+		cur = next
+	}
+
+	th := TagHandler{HandlerFn: handleValidateTag}
+	err := th.Handle(root)
+	if !errors.Is(err, ErrMaxDepthExceeded) {
+		t.Fatalf("expected ErrMaxDepthExceeded, got %v", err)
+	}
+}
+
+func TestDispatchRunsHandlersInSetOrder(t *testing.T) {
+	type S struct {
+		Field string
+	}
+
+	var calls []string
+	record := func(name string) HandlerFn {
+		return func(parent, value reflect.Value, field reflect.StructField, prefix string) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	th := (&TagHandler{}).
+		Register("b", record("b")).
+		Register("a", record("a")).
+		SetOrder([]string{"a", "b"})
+
+	if err := th.Handle(S{Field: "x"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(calls) != 2 || calls[0] != "a" || calls[1] != "b" {
+		t.Fatalf("expected handlers to run in SetOrder order [a b], got %v", calls)
+	}
+}
+
+func TestDispatchErrSkipFieldStopsRemainingHandlers(t *testing.T) {
+	type S struct {
+		Field string
+	}
+
+	var calls []string
+	th := (&TagHandler{}).
+		Register("a", func(parent, value reflect.Value, field reflect.StructField, prefix string) error {
+			calls = append(calls, "a")
+			return ErrSkipField
+		}).
+		Register("b", func(parent, value reflect.Value, field reflect.StructField, prefix string) error {
+			calls = append(calls, "b")
+			return nil
+		}).
+		SetOrder([]string{"a", "b"})
+
+	if err := th.Handle(S{Field: "x"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(calls) != 1 || calls[0] != "a" {
+		t.Fatalf("expected ErrSkipField to stop the remaining handlers for the field, got calls %v", calls)
+	}
+}
+
+var (
+	errHandlerA = errors.New("handler a failed")
+	errHandlerB = errors.New("handler b failed")
+)
+
+func TestDispatchAggregatesErrorsAcrossHandlers(t *testing.T) {
+	type S struct {
+		Field string
+	}
+
+	th := (&TagHandler{}).
+		Register("a", func(parent, value reflect.Value, field reflect.StructField, prefix string) error {
+			return errHandlerA
+		}).
+		Register("b", func(parent, value reflect.Value, field reflect.StructField, prefix string) error {
+			return errHandlerB
+		}).
+		SetOrder([]string{"a", "b"})
+
+	err := th.Handle(S{Field: "x"})
+	if !errors.Is(err, errHandlerA) {
+		t.Fatalf("expected joined error to include handler a's error, got %v", err)
+	}
+	if !errors.Is(err, errHandlerB) {
+		t.Fatalf("expected joined error to include handler b's error, got %v", err)
+	}
+}
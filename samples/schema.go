@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a draft-07 JSON Schema document, restricted to the subset
+// this package needs to describe tagged structs.
+type Schema struct {
+	Schema  string        `json:"$schema,omitempty"`
+	Ref     string        `json:"$ref,omitempty"`
+	Type    string        `json:"type,omitempty"`
+	Format  string        `json:"format,omitempty"`
+	Pattern string        `json:"pattern,omitempty"`
+	Enum    []interface{} `json:"enum,omitempty"`
+
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+
+	AdditionalProperties *Schema `json:"additionalProperties,omitempty"`
+
+	Minimum   *float64 `json:"minimum,omitempty"`
+	Maximum   *float64 `json:"maximum,omitempty"`
+	MinLength *int     `json:"minLength,omitempty"`
+	MaxLength *int     `json:"maxLength,omitempty"`
+	MinItems  *int     `json:"minItems,omitempty"`
+	MaxItems  *int     `json:"maxItems,omitempty"`
+
+	Defs map[string]*Schema `json:"$defs,omitempty"`
+}
+
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// Schema builds a JSON Schema document for the zero value of any struct
+// type, reusing the validate tag parsing (parseRules, splitDive). It walks
+// the type with its own reflect.Type traversal (defineStruct,
+// schemaForGoType), not the value walk of handleValue. Nested struct types, including
+// recursive ones like Person.Friends, are emitted once as $defs entries
+// and referenced by $ref.
+func (th *TagHandler) Schema(v interface{}) (*Schema, error) {
+	typ := reflect.TypeOf(v)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("schema: %T is not a struct", v)
+	}
+
+	defs := map[string]*Schema{}
+	if err := defineStruct(typ, defs); err != nil {
+		return nil, err
+	}
+
+	return &Schema{
+		Schema: jsonSchemaDraft,
+		Ref:    defRef(typ.Name()),
+		Defs:   defs,
+	}, nil
+}
+
+func defRef(name string) string {
+	return "#/$defs/" + name
+}
+
+// defineStruct adds typ's schema to defs under its type name, recursing
+// into struct-typed fields. It reserves the def entry before recursing so
+// a type that refers back to itself (directly or through a slice, as
+// Person.Friends does) terminates instead of looping.
+func defineStruct(typ reflect.Type, defs map[string]*Schema) error {
+	name := typ.Name()
+	if name == "" {
+		return fmt.Errorf("schema: anonymous struct types are not supported")
+	}
+	if _, ok := defs[name]; ok {
+		return nil
+	}
+
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	defs[name] = s
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported, same as encoding/json
+		}
+
+		propName, ok, _ := jsonFieldInfo(field)
+		if !ok {
+			continue
+		}
+
+		propSchema, required, err := schemaForField(field, defs)
+		if err != nil {
+			return err
+		}
+		s.Properties[propName] = propSchema
+		if required {
+			s.Required = append(s.Required, propName)
+		}
+	}
+	return nil
+}
+
+// jsonFieldInfo mirrors encoding/json's own tag handling: the name comes
+// from json:"name", falling back to the Go field name, and json:"-"
+// drops the field from the schema entirely.
+func jsonFieldInfo(field reflect.StructField) (name string, ok bool, omitempty bool) {
+	tag, hasTag := field.Tag.Lookup("json")
+	if !hasTag {
+		return field.Name, true, false
+	}
+
+	parts := strings.Split(tag, ",")
+	if parts[0] == "-" && len(parts) == 1 {
+		return "", false, false
+	}
+
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, true, omitempty
+}
+
+// schemaForField builds the property schema for a struct field and
+// reports whether the validate tag marks it required, applying the same
+// rule DSL the validator uses: required -> required array, min/max ->
+// length or numeric bounds depending on kind, gte/lte -> numeric bounds,
+// oneof -> enum, regexp -> pattern, email/url/uuid -> format.
+func schemaForField(field reflect.StructField, defs map[string]*Schema) (*Schema, bool, error) {
+	s, err := schemaForGoType(field.Type, defs)
+	if err != nil {
+		return nil, false, err
+	}
+
+	tag, ok := field.Tag.Lookup("validate")
+	if !ok {
+		return s, false, nil
+	}
+
+	top, _, _ := splitDive(parseRules(tag))
+	kind := derefKind(field.Type)
+
+	var required bool
+	for _, r := range top {
+		switch r.name {
+		case "required":
+			required = true
+		case "min":
+			applyMinMax(s, kind, r.param, false)
+		case "max":
+			applyMinMax(s, kind, r.param, true)
+		case "gte":
+			if f, err := strconv.ParseFloat(r.param, 64); err == nil {
+				s.Minimum = &f
+			}
+		case "lte":
+			if f, err := strconv.ParseFloat(r.param, 64); err == nil {
+				s.Maximum = &f
+			}
+		case "oneof":
+			s.Enum = enumValues(kind, strings.Fields(r.param))
+		case "regexp":
+			s.Pattern = r.param
+		case "email", "url", "uuid":
+			s.Format = r.name
+		}
+	}
+	return s, required, nil
+}
+
+func applyMinMax(s *Schema, kind reflect.Kind, param string, isMax bool) {
+	switch kind {
+	case reflect.String:
+		if n, err := strconv.Atoi(param); err == nil {
+			if isMax {
+				s.MaxLength = &n
+			} else {
+				s.MinLength = &n
+			}
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if n, err := strconv.Atoi(param); err == nil {
+			if isMax {
+				s.MaxItems = &n
+			} else {
+				s.MinItems = &n
+			}
+		}
+	default:
+		if f, err := strconv.ParseFloat(param, 64); err == nil {
+			if isMax {
+				s.Maximum = &f
+			} else {
+				s.Minimum = &f
+			}
+		}
+	}
+}
+
+// enumValues renders oneof's space-separated options as the JSON type the
+// schema's own "type" will be, so e.g. a `validate:"oneof=1 2 3"` int
+// field gets a numeric enum instead of a string one it can never match.
+func enumValues(kind reflect.Kind, options []string) []interface{} {
+	values := make([]interface{}, len(options))
+	for i, opt := range options {
+		switch {
+		case kind >= reflect.Int && kind <= reflect.Int64, kind >= reflect.Uint && kind <= reflect.Uintptr:
+			if n, err := strconv.ParseInt(opt, 10, 64); err == nil {
+				values[i] = n
+				continue
+			}
+		case kind == reflect.Float32 || kind == reflect.Float64:
+			if f, err := strconv.ParseFloat(opt, 64); err == nil {
+				values[i] = f
+				continue
+			}
+		}
+		values[i] = opt
+	}
+	return values
+}
+
+func derefKind(t reflect.Type) reflect.Kind {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind()
+}
+
+// schemaForGoType builds a schema for a Go type on its own, independent
+// of any struct field it came from: the element type of a slice/map, or
+// (via defineStruct) a nested struct.
+func schemaForGoType(t reflect.Type, defs map[string]*Schema) (*Schema, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return &Schema{Type: "string", Format: "date-time"}, nil
+		}
+		if err := defineStruct(t, defs); err != nil {
+			return nil, err
+		}
+		return &Schema{Ref: defRef(t.Name())}, nil
+
+	case reflect.Slice, reflect.Array:
+		item, err := schemaForGoType(t.Elem(), defs)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: item}, nil
+
+	case reflect.Map:
+		item, err := schemaForGoType(t.Elem(), defs)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: item}, nil
+
+	default:
+		return scalarSchema(t)
+	}
+}
+
+func scalarSchema(t reflect.Type) (*Schema, error) {
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return &Schema{Type: "string", Format: "duration"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	default:
+		return nil, fmt.Errorf("schema: unsupported kind %s", t.Kind())
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestSchemaRecursiveType(t *testing.T) {
+	schema, err := (&TagHandler{}).Schema(Person{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if schema.Ref != "#/$defs/Person" {
+		t.Fatalf("expected root $ref to Person, got %q", schema.Ref)
+	}
+
+	person, ok := schema.Defs["Person"]
+	if !ok {
+		t.Fatal("expected $defs to contain Person")
+	}
+
+	friends, ok := person.Properties["friends"]
+	if !ok {
+		t.Fatal("expected Person schema to have a friends property")
+	}
+	if friends.Items == nil || friends.Items.Ref != "#/$defs/Person" {
+		t.Fatalf("expected friends items to $ref Person, got %+v", friends.Items)
+	}
+
+	birthYear, ok := person.Properties["birth_year"]
+	if !ok {
+		t.Fatal("expected Person schema to have a birth_year property")
+	}
+	if birthYear.Minimum == nil || *birthYear.Minimum != 1900 {
+		t.Fatalf("expected birth_year minimum 1900, got %+v", birthYear.Minimum)
+	}
+	if birthYear.Maximum == nil || *birthYear.Maximum != 2025 {
+		t.Fatalf("expected birth_year maximum 2025, got %+v", birthYear.Maximum)
+	}
+
+	found := false
+	for _, name := range person.Required {
+		if name == "birth_year" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected birth_year to be required, got %v", person.Required)
+	}
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ValidationErrors collects every rule violation found while validating a
+// single field, rather than stopping at the first one.
+type ValidationErrors []error
+
+func (ve ValidationErrors) Error() string {
+	msgs := make([]string, len(ve))
+	for i, err := range ve {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// ValidatorFunc implements a single named rule of the validate tag DSL.
+// value is the field (or, when diving into a slice/map, one of its
+// elements/keys) being checked, and param is whatever followed '=' in the
+// rule, e.g. "3" in "min=3".
+type ValidatorFunc func(value reflect.Value, param string) error
+
+var validators = map[string]ValidatorFunc{
+	"required": validateRequired,
+	"len":      validateLen,
+	"min":      validateMin,
+	"max":      validateMax,
+	"gte":      validateGte,
+	"lte":      validateLte,
+	"eq":       validateEq,
+	"ne":       validateNe,
+	"oneof":    validateOneof,
+	"email":    validateEmail,
+	"url":      validateURL,
+	"uuid":     validateUUID,
+	"regexp":   validateRegexp,
+}
+
+// RegisterValidator adds or overrides a named rule, letting callers extend
+// the validate tag DSL with domain-specific checks.
+func RegisterValidator(name string, fn ValidatorFunc) {
+	validators[name] = fn
+}
+
+// rule is a single parsed element of a validate tag, e.g. "min=3" becomes
+// rule{name: "min", param: "3"}.
+type rule struct {
+	name  string
+	param string
+}
+
+func parseRules(tag string) []rule {
+	parts := strings.Split(tag, ",")
+	rules := make([]rule, 0, len(parts))
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		name, param, _ := strings.Cut(part, "=")
+		rules = append(rules, rule{name: name, param: param})
+	}
+	return rules
+}
+
+// splitDive separates a rule list into the rules that apply to the field
+// itself, the rules that apply to map keys (between "keys" and "endkeys"),
+// and the rules that apply to slice/map elements (after "dive").
+func splitDive(rules []rule) (top, keys, elems []rule) {
+	for i, r := range rules {
+		if r.name != "dive" {
+			continue
+		}
+		rest := rules[i+1:]
+		if len(rest) > 0 && rest[0].name == "keys" {
+			for j, rr := range rest[1:] {
+				if rr.name == "endkeys" {
+					return rules[:i], rest[1 : j+1], rest[j+2:]
+				}
+			}
+		}
+		return rules[:i], nil, rest
+	}
+	return rules, nil, nil
+}
+
+func handleValidateTag(parent reflect.Value, value reflect.Value, field reflect.StructField, _ string) error {
+	tag, ok := field.Tag.Lookup("validate")
+	if !ok {
+		return nil
+	}
+
+	top, keyRules, elemRules := splitDive(parseRules(tag))
+
+	var errs ValidationErrors
+	for _, r := range top {
+		if err := applyRule(parent, value, field, r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(elemRules) > 0 || len(keyRules) > 0 {
+		switch value.Kind() {
+		case reflect.Array, reflect.Slice:
+			for i := 0; i < value.Len(); i++ {
+				for _, r := range elemRules {
+					if err := applyRule(parent, value.Index(i), field, r); err != nil {
+						errs = append(errs, err)
+					}
+				}
+			}
+		case reflect.Map:
+			for _, key := range value.MapKeys() {
+				for _, r := range keyRules {
+					if err := applyRule(parent, key, field, r); err != nil {
+						errs = append(errs, err)
+					}
+				}
+				for _, r := range elemRules {
+					if err := applyRule(parent, value.MapIndex(key), field, r); err != nil {
+						errs = append(errs, err)
+					}
+				}
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func applyRule(parent reflect.Value, value reflect.Value, field reflect.StructField, r rule) error {
+	switch r.name {
+	case "eqfield", "nefield":
+		return applyFieldRule(parent, value, field, r)
+	}
+
+	fn, ok := validators[r.name]
+	if !ok {
+		return fmt.Errorf("validate: unknown rule %q on field %s", r.name, field.Name)
+	}
+	if err := fn(value, r.param); err != nil {
+		return fmt.Errorf("invalid field (%v::%v): %v", field.Type, field.Name, err)
+	}
+	return nil
+}
+
+func applyFieldRule(parent reflect.Value, value reflect.Value, field reflect.StructField, r rule) error {
+	other := parent.FieldByName(r.param)
+	if !other.IsValid() {
+		return fmt.Errorf("validate: unknown field %q referenced by %s on %s", r.param, r.name, field.Name)
+	}
+
+	equal := valueToString(value) == valueToString(other)
+	switch r.name {
+	case "eqfield":
+		if !equal {
+			return fmt.Errorf("invalid field (%v::%v): must equal field %s", field.Type, field.Name, r.param)
+		}
+	case "nefield":
+		if equal {
+			return fmt.Errorf("invalid field (%v::%v): must not equal field %s", field.Type, field.Name, r.param)
+		}
+	}
+	return nil
+}
+
+func validateRequired(value reflect.Value, _ string) error {
+	if value.IsZero() {
+		return fmt.Errorf("is required")
+	}
+	return nil
+}
+
+func lengthOf(value reflect.Value) (int, bool) {
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return value.Len(), true
+	default:
+		return 0, false
+	}
+}
+
+func numberOf(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func validateLen(value reflect.Value, param string) error {
+	want, err := strconv.Atoi(param)
+	if err != nil {
+		return fmt.Errorf("len: invalid parameter %q", param)
+	}
+	if n, ok := lengthOf(value); ok {
+		if n != want {
+			return fmt.Errorf("must have length %d, got %d", want, n)
+		}
+		return nil
+	}
+	return fmt.Errorf("len: unsupported kind %s", value.Kind())
+}
+
+func validateMin(value reflect.Value, param string) error {
+	min, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Errorf("min: invalid parameter %q", param)
+	}
+	if n, ok := lengthOf(value); ok {
+		if float64(n) < min {
+			return fmt.Errorf("must have length >= %v, got %d", min, n)
+		}
+		return nil
+	}
+	if n, ok := numberOf(value); ok {
+		if n < min {
+			return fmt.Errorf("must be >= %v, got %v", min, n)
+		}
+		return nil
+	}
+	return fmt.Errorf("min: unsupported kind %s", value.Kind())
+}
+
+func validateMax(value reflect.Value, param string) error {
+	max, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Errorf("max: invalid parameter %q", param)
+	}
+	if n, ok := lengthOf(value); ok {
+		if float64(n) > max {
+			return fmt.Errorf("must have length <= %v, got %d", max, n)
+		}
+		return nil
+	}
+	if n, ok := numberOf(value); ok {
+		if n > max {
+			return fmt.Errorf("must be <= %v, got %v", max, n)
+		}
+		return nil
+	}
+	return fmt.Errorf("max: unsupported kind %s", value.Kind())
+}
+
+func validateGte(value reflect.Value, param string) error {
+	min, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Errorf("gte: invalid parameter %q", param)
+	}
+	n, ok := numberOf(value)
+	if !ok {
+		return fmt.Errorf("gte: unsupported kind %s", value.Kind())
+	}
+	if n < min {
+		return fmt.Errorf("must be >= %v, got %v", min, n)
+	}
+	return nil
+}
+
+func validateLte(value reflect.Value, param string) error {
+	max, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Errorf("lte: invalid parameter %q", param)
+	}
+	n, ok := numberOf(value)
+	if !ok {
+		return fmt.Errorf("lte: unsupported kind %s", value.Kind())
+	}
+	if n > max {
+		return fmt.Errorf("must be <= %v, got %v", max, n)
+	}
+	return nil
+}
+
+func validateEq(value reflect.Value, param string) error {
+	if valueToString(value) != param {
+		return fmt.Errorf("must equal %v, got %v", param, valueToString(value))
+	}
+	return nil
+}
+
+func validateNe(value reflect.Value, param string) error {
+	if valueToString(value) == param {
+		return fmt.Errorf("must not equal %v", param)
+	}
+	return nil
+}
+
+func validateOneof(value reflect.Value, param string) error {
+	str := valueToString(value)
+	for _, opt := range strings.Fields(param) {
+		if str == opt {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of [%s], got %v", param, str)
+}
+
+var (
+	urlRegexp  = regexp.MustCompile(`^https?://`)
+	uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+func validateEmail(value reflect.Value, _ string) error {
+	str := valueToString(value)
+	addr, err := mail.ParseAddress(str)
+	if err != nil || addr.Address != str {
+		return fmt.Errorf("must be a valid email address, got %v", str)
+	}
+	return nil
+}
+
+func validateURL(value reflect.Value, _ string) error {
+	str := valueToString(value)
+	if _, err := url.ParseRequestURI(str); err != nil || !urlRegexp.MatchString(str) {
+		return fmt.Errorf("must be a valid url, got %v", str)
+	}
+	return nil
+}
+
+func validateUUID(value reflect.Value, _ string) error {
+	if !uuidRegexp.MatchString(valueToString(value)) {
+		return fmt.Errorf("must be a valid uuid, got %v", valueToString(value))
+	}
+	return nil
+}
+
+func validateRegexp(value reflect.Value, param string) error {
+	match, err := regexp.Compile(param)
+	if err != nil {
+		return fmt.Errorf("regexp: invalid pattern %q: %v", param, err)
+	}
+	if !match.MatchString(valueToString(value)) {
+		return fmt.Errorf("must match %q, got %v", param, valueToString(value))
+	}
+	return nil
+}
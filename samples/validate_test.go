@@ -0,0 +1,230 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateMinMaxNumericVsLength(t *testing.T) {
+	if err := validateMin(reflect.ValueOf(5), "10"); err == nil {
+		t.Fatal("expected error: 5 is not >= 10")
+	}
+	if err := validateMin(reflect.ValueOf(10), "10"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateMax(reflect.ValueOf(11), "10"); err == nil {
+		t.Fatal("expected error: 11 is not <= 10")
+	}
+
+	if err := validateMin(reflect.ValueOf("ab"), "3"); err == nil {
+		t.Fatal("expected error: length 2 is not >= 3")
+	}
+	if err := validateMin(reflect.ValueOf("abc"), "3"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateMax(reflect.ValueOf([]int{1, 2, 3}), "2"); err == nil {
+		t.Fatal("expected error: length 3 is not <= 2")
+	}
+}
+
+func TestValidateGteLte(t *testing.T) {
+	if err := validateGte(reflect.ValueOf(1899), "1900"); err == nil {
+		t.Fatal("expected error: 1899 is not >= 1900")
+	}
+	if err := validateGte(reflect.ValueOf(1900), "1900"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateLte(reflect.ValueOf(2026), "2025"); err == nil {
+		t.Fatal("expected error: 2026 is not <= 2025")
+	}
+	if err := validateLte(reflect.ValueOf(2025), "2025"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateOneof(t *testing.T) {
+	if err := validateOneof(reflect.ValueOf("blue"), "red green blue"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateOneof(reflect.ValueOf("purple"), "red green blue"); err == nil {
+		t.Fatal("expected error: purple is not one of red green blue")
+	}
+}
+
+type tagged struct {
+	Codes []string `validate:"dive,oneof=a b c"`
+}
+
+func TestHandleValidateTagDive(t *testing.T) {
+	th := TagHandler{HandlerFn: handleValidateTag}
+
+	if err := th.Handle(tagged{Codes: []string{"a", "b", "c"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := th.Handle(tagged{Codes: []string{"a", "z"}})
+	if err == nil {
+		t.Fatal("expected error: z is not a valid code")
+	}
+	var ve ValidationErrors
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationErrors, got %T: %v", err, err)
+	}
+	if len(ve) != 1 {
+		t.Fatalf("expected exactly one element failure, got %d: %v", len(ve), ve)
+	}
+}
+
+type taggedMap struct {
+	Scores map[string]int `validate:"dive,keys,oneof=a b,endkeys,gte=0,lte=10"`
+}
+
+func TestHandleValidateTagKeysEndkeys(t *testing.T) {
+	th := TagHandler{HandlerFn: handleValidateTag}
+
+	if err := th.Handle(taggedMap{Scores: map[string]int{"a": 5, "b": 10}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := th.Handle(taggedMap{Scores: map[string]int{"z": 5, "b": 20}})
+	if err == nil {
+		t.Fatal("expected errors: bad key and out-of-range value")
+	}
+	var ve ValidationErrors
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationErrors, got %T: %v", err, err)
+	}
+	if len(ve) != 2 {
+		t.Fatalf("expected both the bad key and the bad value to be reported, got %d: %v", len(ve), ve)
+	}
+}
+
+type passwordForm struct {
+	Password string `validate:"required"`
+	Confirm  string `validate:"eqfield=Password"`
+	Other    string `validate:"nefield=Password"`
+}
+
+func TestHandleValidateTagFieldRules(t *testing.T) {
+	th := TagHandler{HandlerFn: handleValidateTag}
+
+	if err := th.Handle(passwordForm{Password: "hunter2", Confirm: "hunter2", Other: "different"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := th.Handle(passwordForm{Password: "hunter2", Confirm: "mismatch", Other: "different"}); err == nil {
+		t.Fatal("expected eqfield error: Confirm must equal Password")
+	}
+
+	if err := th.Handle(passwordForm{Password: "hunter2", Confirm: "hunter2", Other: "hunter2"}); err == nil {
+		t.Fatal("expected nefield error: Other must not equal Password")
+	}
+}
+
+type multiRule struct {
+	Name string `validate:"required,min=3,max=5"`
+}
+
+func TestHandleValidateTagAccumulatesErrors(t *testing.T) {
+	th := TagHandler{HandlerFn: handleValidateTag}
+
+	err := th.Handle(multiRule{Name: ""})
+	if err == nil {
+		t.Fatal("expected errors: empty name fails both required and min")
+	}
+	var ve ValidationErrors
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationErrors, got %T: %v", err, err)
+	}
+	if len(ve) != 2 {
+		t.Fatalf("expected both required and min failures to accumulate, got %d: %v", len(ve), ve)
+	}
+}
+
+func TestValidateLen(t *testing.T) {
+	if err := validateLen(reflect.ValueOf("abcd"), "4"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateLen(reflect.ValueOf("abc"), "4"); err == nil {
+		t.Fatal("expected error: length 3 != 4")
+	}
+	if err := validateLen(reflect.ValueOf([]int{1, 2}), "2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateLen(reflect.ValueOf(1), "1"); err == nil {
+		t.Fatal("expected error: len is unsupported on numeric kinds")
+	}
+}
+
+func TestValidateEqNe(t *testing.T) {
+	if err := validateEq(reflect.ValueOf("blue"), "blue"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateEq(reflect.ValueOf("blue"), "red"); err == nil {
+		t.Fatal("expected error: blue != red")
+	}
+	if err := validateNe(reflect.ValueOf("blue"), "red"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateNe(reflect.ValueOf("blue"), "blue"); err == nil {
+		t.Fatal("expected error: blue must not equal blue")
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	if err := validateURL(reflect.ValueOf("https://example.com/path"), ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateURL(reflect.ValueOf("not a url"), ""); err == nil {
+		t.Fatal("expected error: not a url")
+	}
+	if err := validateURL(reflect.ValueOf("ftp://example.com"), ""); err == nil {
+		t.Fatal("expected error: scheme must be http(s)")
+	}
+}
+
+func TestValidateUUID(t *testing.T) {
+	if err := validateUUID(reflect.ValueOf("123e4567-e89b-12d3-a456-426614174000"), ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateUUID(reflect.ValueOf("not-a-uuid"), ""); err == nil {
+		t.Fatal("expected error: not-a-uuid")
+	}
+}
+
+func TestValidateRegexp(t *testing.T) {
+	if err := validateRegexp(reflect.ValueOf("abc123"), "^[a-z]+[0-9]+$"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateRegexp(reflect.ValueOf("123abc"), "^[a-z]+[0-9]+$"); err == nil {
+		t.Fatal("expected error: pattern does not match")
+	}
+	if err := validateRegexp(reflect.ValueOf("x"), "("); err == nil {
+		t.Fatal("expected error: invalid regexp pattern")
+	}
+}
+
+type withCustomRule struct {
+	Color string `validate:"iscolor"`
+}
+
+func TestRegisterValidatorRoundTrip(t *testing.T) {
+	RegisterValidator("iscolor", func(value reflect.Value, param string) error {
+		switch valueToString(value) {
+		case "red", "green", "blue":
+			return nil
+		default:
+			return errors.New("is not a color")
+		}
+	})
+
+	th := TagHandler{HandlerFn: handleValidateTag}
+
+	if err := th.Handle(withCustomRule{Color: "green"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := th.Handle(withCustomRule{Color: "purple"}); err == nil {
+		t.Fatal("expected error: purple is not registered as a color")
+	}
+}